@@ -0,0 +1,213 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lightlace/zypper-docker/registry"
+	"github.com/mssola/dockerclient"
+)
+
+// containerTimeout is the maximum amount of time we wait for a private
+// container to finish running a command before giving up on it.
+const containerTimeout = 500 * time.Millisecond
+
+// privateContainerName returns the name given to the throwaway container
+// used to run a command inside of the given image.
+func privateContainerName(image string) string {
+	return "zypper-docker-private-" + image
+}
+
+// DockerClient abstracts the subset of the Docker API that zypper-docker
+// needs. It's implemented by realDockerClient, which wraps the real
+// *dockerclient.DockerClient, and, for tests, by zyppertest.MockClient.
+type DockerClient interface {
+	CreateContainer(config *dockerclient.ContainerConfig, name string) (string, error)
+	StartContainer(id string, config *dockerclient.HostConfig) error
+	ContainerLogs(id string, options *dockerclient.LogOptions) (io.ReadCloser, error)
+	Wait(id string) <-chan dockerclient.WaitResult
+	RemoveContainer(id string, force, volumes bool) error
+	ListImages(all bool) ([]*dockerclient.Image, error)
+
+	// TrustedPull pulls image, refusing to complete if Docker Content Trust
+	// can't verify the signature against a trusted signer.
+	TrustedPull(image string) error
+
+	// TrustedCommit commits the container identified by id as repo:tag and,
+	// when Content Trust is enabled, signs it with the local notary keys
+	// before it can be pushed.
+	TrustedCommit(id, repo, tag string) (string, error)
+
+	// PullImage pulls name, authenticating with cfg.
+	PullImage(name string, cfg *registry.Config) error
+}
+
+// preparePatch fires the OnPrePatch hook for image and, when Content Trust
+// is enabled, verifies it before anything is run in it.
+func preparePatch(image string) error {
+	firePrePatch(image)
+
+	if trustEnabled() {
+		if err := getDockerClient().TrustedPull(image); err != nil {
+			log.Println("Trust verification failed:", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// runCommandInContainer creates a container based on the given image, runs
+// cmd inside of it and returns its combined output. When streaming is true
+// the output is also copied to stdout as it's produced. The container is
+// always removed before returning.
+func runCommandInContainer(image string, cmd []string, streaming bool) (string, error) {
+	if err := preparePatch(image); err != nil {
+		return "", err
+	}
+
+	id, output, err := createAndRunContainer(image, cmd, streaming)
+	if id != "" {
+		removeContainer(id)
+	}
+	return output, err
+}
+
+// runPatchContainer is like runCommandInContainer, except it leaves the
+// container running on success so that the caller (patchImage) can commit
+// it before removing it.
+func runPatchContainer(image string, cmd []string, streaming bool) (string, string, error) {
+	if err := preparePatch(image); err != nil {
+		return "", "", err
+	}
+	return createAndRunContainer(image, cmd, streaming)
+}
+
+// createAndRunContainer creates a container from image, runs cmd inside of
+// it and returns its id together with its combined output. When streaming
+// is true the output is also copied to stdout as it's produced. Unlike
+// runCommandInContainer, it never removes the container itself: that's the
+// caller's responsibility, since a non-empty id may need to be committed
+// first.
+func createAndRunContainer(image string, cmd []string, streaming bool) (string, string, error) {
+	client := getDockerClient()
+
+	config := &dockerclient.ContainerConfig{
+		Image: image,
+		Cmd:   cmd,
+	}
+
+	id, err := client.CreateContainer(config, "")
+	if err != nil && strings.Contains(err.Error(), "no such image") {
+		if perr := pullImage(image); perr != nil {
+			log.Println("Create failed:", err)
+			return "", "", perr
+		}
+		id, err = client.CreateContainer(config, "")
+	}
+	if err != nil {
+		log.Println("Create failed:", err)
+		return "", "", err
+	}
+
+	hostConfig := &dockerclient.HostConfig{Binds: zyppCacheBinds()}
+	if err := client.StartContainer(id, hostConfig); err != nil {
+		log.Println("Start failed:", err)
+		return id, "", err
+	}
+
+	reader, err := client.ContainerLogs(id, &dockerclient.LogOptions{Stdout: true, Stderr: true})
+	if err != nil {
+		return id, "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	var buffer bytes.Buffer
+	dest := io.Writer(&buffer)
+	if streaming {
+		dest = io.MultiWriter(&buffer, os.Stdout)
+	}
+
+	if _, err = io.Copy(dest, reader); err != nil {
+		return id, buffer.String(), err
+	}
+
+	result := <-client.Wait(id)
+	if result.ExitCode != 0 {
+		return id, buffer.String(), fmt.Errorf("Command exited with status %d", result.ExitCode)
+	}
+
+	return id, buffer.String(), nil
+}
+
+// checkCommandInImage spins up a private container from image, runs cmd in
+// it and reports whether it exited successfully. The container is always
+// removed before returning, regardless of the outcome.
+func checkCommandInImage(image string, cmd string) bool {
+	client := getDockerClient()
+
+	config := &dockerclient.ContainerConfig{
+		Image: image,
+		Cmd:   strings.Fields(cmd),
+	}
+
+	name := privateContainerName(image)
+	id, err := client.CreateContainer(config, name)
+	if err != nil {
+		log.Println("Create failed:", err)
+		return false
+	}
+
+	hostConfig := &dockerclient.HostConfig{Binds: zyppCacheBinds()}
+	if err := client.StartContainer(id, hostConfig); err != nil {
+		log.Println("Start failed:", err)
+		removeContainer(id)
+		return false
+	}
+
+	select {
+	case result := <-client.Wait(id):
+		removeContainer(id)
+		if result.Error != nil {
+			log.Println("Wait failed:", result.Error)
+			return false
+		}
+		return result.ExitCode == 0
+	case <-time.After(containerTimeout):
+		log.Println("Timed out when waiting for a container.")
+		log.Println("Killing container", id)
+		removeContainer(id)
+		return false
+	}
+}
+
+// removeContainer removes the container identified by id, logging (but not
+// returning) any error so that callers can treat it as a best-effort
+// cleanup step.
+func removeContainer(id string) {
+	client := getDockerClient()
+	defer fireRemoveContainer(id)
+	if err := client.RemoveContainer(id, true, true); err != nil {
+		log.Println("Remove failed:", err)
+		return
+	}
+	log.Println("Removed container", id)
+}
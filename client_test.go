@@ -16,6 +16,7 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -24,15 +25,15 @@ import (
 	"testing"
 	"time"
 
-	"github.com/mssola/dockerclient"
+	"github.com/lightlace/zypper-docker/zyppertest"
 )
 
 func TestMockClient(t *testing.T) {
-	dockerClient = &mockClient{}
+	dockerClient = zyppertest.New()
 
 	client := getDockerClient()
 	to := reflect.TypeOf(client)
-	if to.String() != "*main.mockClient" {
+	if to.String() != "*zyppertest.MockClient" {
 		t.Fatal("Wrong type for the client")
 	}
 
@@ -51,10 +52,11 @@ func TestDockerClient(t *testing.T) {
 	// implementation of it for more details.
 	client := getDockerClient()
 
-	docker, ok := client.(*dockerclient.DockerClient)
+	real, ok := client.(*realDockerClient)
 	if !ok {
-		t.Fatal("Could not cast to dockerclient.DockerClient")
+		t.Fatal("Could not cast to realDockerClient")
 	}
+	docker := real.DockerClient
 
 	if docker.URL.Scheme != "http" {
 		t.Fatalf("Unexpected scheme: %v\n", docker.URL.Scheme)
@@ -67,7 +69,7 @@ func TestDockerClient(t *testing.T) {
 }
 
 func TestRunCommandInContainerCreateFailure(t *testing.T) {
-	dockerClient = &mockClient{createFail: true}
+	dockerClient = zyppertest.New(zyppertest.WithCreateFailure())
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -79,8 +81,117 @@ func TestRunCommandInContainerCreateFailure(t *testing.T) {
 	}
 }
 
+func TestRunCommandInContainerPullsMissingImage(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithMissingImage())
+
+	if _, err := runCommandInContainer("opensuse", []string{}, false); err != nil {
+		t.Fatalf("It should've pulled the missing image and succeeded: %v\n", err)
+	}
+}
+
+func TestRunCommandInContainerPullAuthFailure(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithMissingImage(), zyppertest.WithAuthFailure())
+
+	buffer := bytes.NewBuffer([]byte{})
+	log.SetOutput(buffer)
+	if _, err := runCommandInContainer("opensuse", []string{}, false); err == nil {
+		t.Fatal("It should've failed\n")
+	}
+	if !strings.Contains(buffer.String(), "Create failed") {
+		t.Fatal("It should've logged something expected\n")
+	}
+}
+
+func TestRunCommandInContainerFallsBackToMirror(t *testing.T) {
+	home, err := ioutil.TempDir("", "zypper_docker_home")
+	if err != nil {
+		t.Fatal("Could not setup test")
+	}
+	defer func() { _ = os.RemoveAll(home) }()
+
+	original := os.Getenv("HOME")
+	defer func() { _ = os.Setenv("HOME", original) }()
+	_ = os.Setenv("HOME", home)
+
+	confDir := home + "/.config/zypper-docker"
+	if err := os.MkdirAll(confDir, 0755); err != nil {
+		t.Fatal("Could not setup test")
+	}
+	conf := `{"mirrors": ["mirror.example.com"]}`
+	if err := ioutil.WriteFile(confDir+"/config.json", []byte(conf), 0644); err != nil {
+		t.Fatal("Could not setup test")
+	}
+
+	mock := zyppertest.New(zyppertest.WithMissingImage(), zyppertest.WithAuthFailure())
+	dockerClient = mock
+
+	if _, err := runCommandInContainer("opensuse", []string{}, false); err != nil {
+		t.Fatalf("It should've fallen back to the mirror and succeeded: %v\n", err)
+	}
+	if !mock.MirrorHit() {
+		t.Fatal("It should've pulled from the configured mirror\n")
+	}
+}
+
+func TestBindsWithSELinuxDisabled(t *testing.T) {
+	original := selinuxEnabled
+	selinuxEnabled = func() bool { return false }
+	defer func() { selinuxEnabled = original }()
+
+	bind := bindsWithSELinux("/var/cache/zypp", "/var/cache/zypp", false)
+	if bind != "/var/cache/zypp:/var/cache/zypp" {
+		t.Fatalf("Unexpected bind spec: %v\n", bind)
+	}
+}
+
+func TestBindsWithSELinuxEnforcing(t *testing.T) {
+	original := selinuxEnabled
+	selinuxEnabled = func() bool { return true }
+	defer func() { selinuxEnabled = original }()
+
+	bind := bindsWithSELinux("/var/cache/zypp", "/var/cache/zypp", false)
+	if bind != "/var/cache/zypp:/var/cache/zypp:Z" {
+		t.Fatalf("Unexpected bind spec: %v\n", bind)
+	}
+}
+
+func TestBindsWithSELinuxEnforcingShared(t *testing.T) {
+	original := selinuxEnabled
+	selinuxEnabled = func() bool { return true }
+	defer func() { selinuxEnabled = original }()
+
+	bind := bindsWithSELinux("/var/cache/zypp", "/var/cache/zypp", true)
+	if bind != "/var/cache/zypp:/var/cache/zypp:z" {
+		t.Fatalf("Unexpected bind spec: %v\n", bind)
+	}
+}
+
+func TestRunCommandInContainerMountsZyppCache(t *testing.T) {
+	original := selinuxEnabled
+	selinuxEnabled = func() bool { return true }
+	defer func() { selinuxEnabled = original }()
+
+	zyppCachePath = "/host/cache/zypp"
+	defer func() { zyppCachePath = "" }()
+
+	mock := zyppertest.New()
+	dockerClient = mock
+
+	if _, err := runCommandInContainer("opensuse", []string{}, false); err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+
+	hostConfig := mock.LastHostConfig()
+	if hostConfig == nil || len(hostConfig.Binds) != 1 {
+		t.Fatal("Expected the zypp cache to be mounted\n")
+	}
+	if hostConfig.Binds[0] != "/host/cache/zypp:/var/cache/zypp:z" {
+		t.Fatalf("Unexpected bind spec: %v\n", hostConfig.Binds[0])
+	}
+}
+
 func TestRunCommandInContainerStartFailure(t *testing.T) {
-	dockerClient = &mockClient{startFail: true}
+	dockerClient = zyppertest.New(zyppertest.WithStartFailure())
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -102,7 +213,7 @@ func TestRunCommandInContainerStartFailure(t *testing.T) {
 }
 
 func TestRunCommandInContainerContainerLogsFailure(t *testing.T) {
-	dockerClient = &mockClient{logFail: true}
+	dockerClient = zyppertest.New(zyppertest.WithLogFailure())
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -117,8 +228,7 @@ func TestRunCommandInContainerContainerLogsFailure(t *testing.T) {
 }
 
 func TestRunCommandInContainerStreaming(t *testing.T) {
-	mock := mockClient{}
-	dockerClient = &mock
+	dockerClient = zyppertest.New()
 
 	temp, err := ioutil.TempFile("", "zypper_docker")
 	if err != nil {
@@ -149,13 +259,13 @@ func TestRunCommandInContainerStreaming(t *testing.T) {
 		t.Fatal("Could not read temporary file")
 	}
 
-	if !strings.Contains(string(b), "streaming buffer initialized") {
-		t.Fatal("The streaming buffer should have been initialized\n")
+	if !strings.Contains(string(b), "fake log output") {
+		t.Fatal("The container's log output should have been streamed to stdout\n")
 	}
 }
 
 func TestRunCommandInContainerCommandFailure(t *testing.T) {
-	dockerClient = &mockClient{commandFail: true}
+	dockerClient = zyppertest.New(zyppertest.WithCommandFailure())
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -170,10 +280,10 @@ func TestRunCommandInContainerCommandFailure(t *testing.T) {
 }
 
 func TestCheckCommandInImageWaitFailed(t *testing.T) {
-	dockerClient = &mockClient{
-		waitFail:  true,
-		waitSleep: 100 * time.Millisecond,
-	}
+	dockerClient = zyppertest.New(
+		zyppertest.WithWaitFailure(),
+		zyppertest.WithWaitSleep(100*time.Millisecond),
+	)
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -194,7 +304,7 @@ func TestCheckCommandInImageWaitFailed(t *testing.T) {
 }
 
 func TestCheckCommandInImageWaitTimedOut(t *testing.T) {
-	dockerClient = &mockClient{waitSleep: containerTimeout * 2}
+	dockerClient = zyppertest.New(zyppertest.WithWaitSleep(containerTimeout * 2))
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -215,7 +325,7 @@ func TestCheckCommandInImageWaitTimedOut(t *testing.T) {
 }
 
 func TestCheckCommandInImageSuccess(t *testing.T) {
-	dockerClient = &mockClient{waitSleep: 100 * time.Millisecond}
+	dockerClient = zyppertest.New(zyppertest.WithWaitSleep(100 * time.Millisecond))
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -232,8 +342,57 @@ func TestCheckCommandInImageSuccess(t *testing.T) {
 	}
 }
 
+func TestRunCommandInContainerTrustFailure(t *testing.T) {
+	trustFlag = true
+	defer func() { trustFlag = false }()
+
+	dockerClient = zyppertest.New(zyppertest.WithTrustFailure())
+
+	buffer := bytes.NewBuffer([]byte{})
+	log.SetOutput(buffer)
+	if _, err := runCommandInContainer("opensuse", []string{}, false); err == nil {
+		t.Fatal("It should've failed\n")
+	}
+	if !strings.Contains(buffer.String(), "Trust verification failed") {
+		t.Fatal("It should've logged something expected\n")
+	}
+}
+
+func TestRunCommandInContainerTrustDisabled(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithTrustFailure())
+
+	if _, err := runCommandInContainer("opensuse", []string{}, false); err != nil {
+		t.Fatal("It shouldn't have consulted content trust when it's disabled\n")
+	}
+}
+
+func TestCommitPatchedImageSigns(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	image, err := commitPatchedImage("abc123", "opensuse", "patched")
+	if err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+	if image != "opensuse:patched" {
+		t.Fatalf("Unexpected image reference: %v\n", image)
+	}
+}
+
+func TestCommitPatchedImageSignFailure(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithSignFailure())
+
+	buffer := bytes.NewBuffer([]byte{})
+	log.SetOutput(buffer)
+	if _, err := commitPatchedImage("abc123", "opensuse", "patched"); err == nil {
+		t.Fatal("It should've failed\n")
+	}
+	if !strings.Contains(buffer.String(), "Signing failed") {
+		t.Fatal("It should've logged something expected\n")
+	}
+}
+
 func TestRemoveContainerFail(t *testing.T) {
-	dockerClient = &mockClient{removeFail: true}
+	dockerClient = zyppertest.New(zyppertest.WithRemoveFailure())
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -248,4 +407,106 @@ func TestRemoveContainerFail(t *testing.T) {
 	if len(lines) != 2 {
 		t.Fatal("Wrong number of lines")
 	}
-}
\ No newline at end of file
+}
+
+// recordingHook is a Hook that records the events it receives, so tests can
+// assert on which ones fired without spinning up real executables or HTTP
+// servers.
+type recordingHook struct {
+	prePatch  []string
+	postPatch []string
+	removed   []string
+	failPost  bool
+}
+
+func (h *recordingHook) OnPrePatch(image string) {
+	h.prePatch = append(h.prePatch, image)
+}
+
+func (h *recordingHook) OnPostPatch(image, newTag string, patches []Patch) error {
+	h.postPatch = append(h.postPatch, image+":"+newTag)
+	if h.failPost {
+		return fmt.Errorf("hook failed")
+	}
+	return nil
+}
+
+func (h *recordingHook) OnRemoveContainer(id string) {
+	h.removed = append(h.removed, id)
+}
+
+func TestHooksFireOnRemoveContainerSuccess(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	hook := &recordingHook{}
+	hooks = []Hook{hook}
+	defer func() { hooks = nil }()
+
+	removeContainer("abc123")
+
+	if len(hook.removed) != 1 || hook.removed[0] != "abc123" {
+		t.Fatal("Hook should've been notified of the removed container\n")
+	}
+}
+
+func TestHooksFireOnRemoveContainerFailure(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithRemoveFailure())
+
+	hook := &recordingHook{}
+	hooks = []Hook{hook}
+	defer func() { hooks = nil }()
+
+	removeContainer("fail")
+
+	if len(hook.removed) != 1 || hook.removed[0] != "fail" {
+		t.Fatal("Hook should've been notified even though the removal failed\n")
+	}
+}
+
+func TestHooksFireOnPrePatch(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	hook := &recordingHook{}
+	hooks = []Hook{hook}
+	defer func() { hooks = nil }()
+
+	if _, err := runCommandInContainer("opensuse", []string{"true"}, false); err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+
+	if len(hook.prePatch) != 1 || hook.prePatch[0] != "opensuse" {
+		t.Fatal("Hook should've been notified before patching started\n")
+	}
+}
+
+func TestHooksFireOnPostPatchSuccess(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	hook := &recordingHook{}
+	hooks = []Hook{hook}
+	defer func() { hooks = nil }()
+
+	if _, err := commitPatchedImage("abc123", "opensuse", "patched"); err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+
+	if len(hook.postPatch) != 1 || hook.postPatch[0] != "opensuse:patched" {
+		t.Fatal("Hook should've been notified of the successful commit\n")
+	}
+}
+
+func TestHooksDoNotFireOnPostPatchFailure(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithSignFailure())
+
+	hook := &recordingHook{}
+	hooks = []Hook{hook}
+	defer func() { hooks = nil }()
+
+	if _, err := commitPatchedImage("abc123", "opensuse", "patched"); err == nil {
+		t.Fatal("It should've failed\n")
+	}
+
+	if len(hook.postPatch) != 0 {
+		t.Fatal("Hook shouldn't have been notified of a failed commit\n")
+	}
+}
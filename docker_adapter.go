@@ -0,0 +1,127 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lightlace/zypper-docker/registry"
+	"github.com/mssola/dockerclient"
+)
+
+// realDockerClient adapts *dockerclient.DockerClient to our DockerClient
+// interface. dockerclient only talks to the daemon's low-level API, which
+// has no notion of Content Trust or registry auth, so the trust- and
+// registry-aware methods shell out to the docker CLI, which already knows
+// how to drive notary and read ~/.docker/config.json.
+type realDockerClient struct {
+	*dockerclient.DockerClient
+}
+
+// newRealDockerClient wraps client so it satisfies DockerClient.
+func newRealDockerClient(client *dockerclient.DockerClient) *realDockerClient {
+	return &realDockerClient{client}
+}
+
+// TrustedPull pulls image with DOCKER_CONTENT_TRUST=1, so the docker CLI
+// refuses the pull unless the image is signed by a trusted signer.
+func (c *realDockerClient) TrustedPull(image string) error {
+	cmd := exec.Command("docker", "pull", image)
+	cmd.Env = append(os.Environ(), "DOCKER_CONTENT_TRUST=1")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// TrustedCommit commits the container identified by id as repo:tag and,
+// when Content Trust is enabled, signs it with the local notary keys via
+// `docker trust sign`.
+func (c *realDockerClient) TrustedCommit(id, repo, tag string) (string, error) {
+	image := fmt.Sprintf("%s:%s", repo, tag)
+
+	commit := exec.Command("docker", "commit", id, image)
+	commit.Stderr = os.Stderr
+	if err := commit.Run(); err != nil {
+		return "", err
+	}
+
+	if !trustEnabled() {
+		return image, nil
+	}
+
+	sign := exec.Command("docker", "trust", "sign", image)
+	sign.Stdout = os.Stdout
+	sign.Stderr = os.Stderr
+	if err := sign.Run(); err != nil {
+		return "", err
+	}
+
+	return image, nil
+}
+
+// PullImage pulls name, authenticating with cfg if it holds credentials for
+// name's registry host.
+func (c *realDockerClient) PullImage(name string, cfg *registry.Config) error {
+	if err := loginForImage(name, cfg); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("docker", "pull", name)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// loginForImage runs `docker login` against the registry host name is
+// pulled from, if cfg has credentials for it. It's a no-op when cfg has
+// none, leaving the daemon's own stored credentials (or an anonymous pull)
+// in effect.
+func loginForImage(name string, cfg *registry.Config) error {
+	host := registryHost(name)
+	auth, ok := cfg.Auths[host]
+	if !ok {
+		return nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+	if err != nil {
+		return fmt.Errorf("could not decode auth for %s: %v", host, err)
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("malformed auth for %s", host)
+	}
+	user, pass := parts[0], parts[1]
+
+	cmd := exec.Command("docker", "login", "--username", user, "--password-stdin", host)
+	cmd.Stdin = strings.NewReader(pass)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// registryHost returns the registry host name is pulled from, defaulting to
+// Docker Hub when name has no explicit host component.
+func registryHost(name string) string {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.Contains(parts[0], ".") || strings.Contains(parts[0], ":")) {
+		return parts[0]
+	}
+	return "docker.io"
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"text/template"
+)
+
+// Image is the subset of Docker image metadata that `images` displays to
+// the user, independent of the underlying Docker client library so it can
+// be marshaled as-is.
+type Image struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	ID         string `json:"id"`
+	Created    string `json:"created"`
+	Size       string `json:"size"`
+}
+
+// Patch is the subset of patch metadata reported to hooks via OnPostPatch.
+type Patch struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+}
+
+// renderImages writes imgs to w using format: "table" (the default
+// fixed-width layout), "json", or a Go text/template expression applied to
+// each image in turn, mirroring `docker images --format`.
+func renderImages(w io.Writer, imgs []Image, format string) error {
+	switch format {
+	case "", "table":
+		return renderImagesTable(w, imgs)
+	case "json":
+		return json.NewEncoder(w).Encode(imgs)
+	default:
+		tmpl, err := template.New("image").Parse(format)
+		if err != nil {
+			return err
+		}
+		for _, img := range imgs {
+			if err := tmpl.Execute(w, img); err != nil {
+				return err
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+}
+
+func renderImagesTable(w io.Writer, imgs []Image) error {
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tVIRTUAL SIZE")
+	for _, img := range imgs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", img.Repository, img.Tag, img.ID, img.Created, img.Size)
+	}
+	return tw.Flush()
+}
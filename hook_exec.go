@@ -0,0 +1,82 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"path/filepath"
+)
+
+// hookEvent is the JSON payload delivered to every configured hook.
+type hookEvent struct {
+	Event       string  `json:"event"`
+	Image       string  `json:"image,omitempty"`
+	NewTag      string  `json:"new_tag,omitempty"`
+	Patches     []Patch `json:"patches,omitempty"`
+	ContainerID string  `json:"container_id,omitempty"`
+}
+
+// execHook runs every executable found in dir, feeding it a hookEvent as
+// JSON on stdin. It's meant to be pointed at
+// ~/.config/zypper-docker/hooks.d/.
+type execHook struct {
+	dir string
+}
+
+// newExecHook creates an execHook that runs the scripts found in dir.
+func newExecHook(dir string) *execHook {
+	return &execHook{dir: dir}
+}
+
+func (h *execHook) run(event hookEvent) error {
+	entries, err := ioutil.ReadDir(h.dir)
+	if err != nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		cmd := exec.Command(filepath.Join(h.dir, entry.Name()))
+		cmd.Stdin = bytes.NewReader(payload)
+		if err := cmd.Run(); err != nil {
+			log.Println("Hook failed:", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (h *execHook) OnPrePatch(image string) {
+	_ = h.run(hookEvent{Event: "pre-patch", Image: image})
+}
+
+func (h *execHook) OnPostPatch(image, newTag string, patches []Patch) error {
+	return h.run(hookEvent{Event: "post-patch", Image: image, NewTag: newTag, Patches: patches})
+}
+
+func (h *execHook) OnRemoveContainer(id string) {
+	_ = h.run(hookEvent{Event: "remove-container", ContainerID: id})
+}
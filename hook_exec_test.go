@@ -0,0 +1,100 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeHookScript drops an executable shell script into dir that copies its
+// stdin to the file at outPath, so tests can inspect what a hook received.
+func writeHookScript(t *testing.T, dir, name, outPath string) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+
+	script := "#!/bin/sh\ncat > " + outPath + "\n"
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal("Could not write hook script:", err)
+	}
+}
+
+func TestExecHookRunsScriptsWithJSONPayload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zypper_docker_hooks")
+	if err != nil {
+		t.Fatal("Could not setup test")
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	out := filepath.Join(dir, "received.json")
+	writeHookScript(t, dir, "capture.sh", out)
+
+	h := newExecHook(dir)
+	h.OnPrePatch("opensuse")
+
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal("The hook script should've run and captured its stdin:", err)
+	}
+
+	var event hookEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatal("The hook should've received valid JSON:", err)
+	}
+	if event.Event != "pre-patch" || event.Image != "opensuse" {
+		t.Fatalf("Unexpected event payload: %+v\n", event)
+	}
+}
+
+func TestExecHookSkipsSubdirectories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zypper_docker_hooks")
+	if err != nil {
+		t.Fatal("Could not setup test")
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0755); err != nil {
+		t.Fatal("Could not setup test")
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	log.SetOutput(buffer)
+
+	h := newExecHook(dir)
+	if err := h.run(hookEvent{Event: "pre-patch", Image: "opensuse"}); err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+	if strings.Contains(buffer.String(), "Hook failed") {
+		t.Fatal("The subdirectory shouldn't have been run as a hook\n")
+	}
+}
+
+func TestExecHookMissingDirIsANoOp(t *testing.T) {
+	h := newExecHook("/no/such/directory")
+	if err := h.run(hookEvent{Event: "pre-patch"}); err != nil {
+		t.Fatal("A missing hooks directory shouldn't be an error\n")
+	}
+}
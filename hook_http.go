@@ -0,0 +1,69 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// httpHook POSTs a hookEvent as JSON to a configured webhook URL.
+type httpHook struct {
+	url    string
+	client *http.Client
+}
+
+// newHTTPHook creates an httpHook that POSTs to url.
+func newHTTPHook(url string) *httpHook {
+	return &httpHook{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (h *httpHook) post(event hookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", h.url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (h *httpHook) OnPrePatch(image string) {
+	if err := h.post(hookEvent{Event: "pre-patch", Image: image}); err != nil {
+		log.Println("Hook failed:", err)
+	}
+}
+
+func (h *httpHook) OnPostPatch(image, newTag string, patches []Patch) error {
+	return h.post(hookEvent{Event: "post-patch", Image: image, NewTag: newTag, Patches: patches})
+}
+
+func (h *httpHook) OnRemoveContainer(id string) {
+	if err := h.post(hookEvent{Event: "remove-container", ContainerID: id}); err != nil {
+		log.Println("Hook failed:", err)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPHookPostsJSONPayload(t *testing.T) {
+	var received hookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatal("The webhook should've received valid JSON:", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	h := newHTTPHook(server.URL)
+	if err := h.post(hookEvent{Event: "remove-container", ContainerID: "abc123"}); err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+
+	if received.Event != "remove-container" || received.ContainerID != "abc123" {
+		t.Fatalf("Unexpected event payload: %+v\n", received)
+	}
+}
+
+func TestHTTPHookTreatsNon2xxAsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	h := newHTTPHook(server.URL)
+	if err := h.post(hookEvent{Event: "pre-patch"}); err == nil {
+		t.Fatal("A non-2xx response should've been reported as an error\n")
+	}
+}
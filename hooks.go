@@ -0,0 +1,62 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "log"
+
+// Hook lets external code observe zypper-docker's patching lifecycle
+// without forking it: pushing patched images to a registry, filing tickets
+// for the CVEs a patch closed, or triggering a redeploy, for instance.
+type Hook interface {
+	// OnPrePatch fires right before image is patched.
+	OnPrePatch(image string)
+
+	// OnPostPatch fires once image has been successfully patched and
+	// committed as newTag. Returning an error only logs it; it never
+	// undoes the commit.
+	OnPostPatch(image, newTag string, patches []Patch) error
+
+	// OnRemoveContainer fires whenever a throwaway container is removed,
+	// whether or not the removal itself succeeded.
+	OnRemoveContainer(id string)
+}
+
+// hooks holds every hook registered through the CLI or the config file.
+var hooks []Hook
+
+// registerHook adds h to the set of hooks notified of patching events.
+func registerHook(h Hook) {
+	hooks = append(hooks, h)
+}
+
+func firePrePatch(image string) {
+	for _, h := range hooks {
+		h.OnPrePatch(image)
+	}
+}
+
+func firePostPatch(image, newTag string, patches []Patch) {
+	for _, h := range hooks {
+		if err := h.OnPostPatch(image, newTag, patches); err != nil {
+			log.Println("Hook failed:", err)
+		}
+	}
+}
+
+func fireRemoveContainer(id string) {
+	for _, h := range hooks {
+		h.OnRemoveContainer(id)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/mssola/dockerclient"
+)
+
+// cacheName is the file name used to persist which images are known to be
+// SUSE based. `images --force` rewrites it; the listing `images` prints
+// doesn't read it.
+const cacheName = "zypper-docker-images.cache"
+
+// CacheData is the on-disk representation of the SUSE image cache: which
+// image ids are known to be SUSE based (Suse) and which aren't (Other).
+type CacheData struct {
+	Valid bool
+	Suse  []string
+	Other []string
+}
+
+// cacheFilePath returns where the image cache lives, honoring
+// XDG_CACHE_HOME like the rest of the XDG base directory aware tools.
+func cacheFilePath() string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(dir, cacheName)
+}
+
+// getCacheFile reads the persisted cache from disk. A missing or corrupt
+// cache is treated as an empty, invalid one rather than an error.
+func getCacheFile() *CacheData {
+	cd := &CacheData{}
+	data, err := ioutil.ReadFile(cacheFilePath())
+	if err != nil {
+		return cd
+	}
+	_ = json.Unmarshal(data, cd)
+	return cd
+}
+
+// flush persists the cache to disk.
+func (cd *CacheData) flush() {
+	data, err := json.Marshal(cd)
+	if err != nil {
+		log.Println("Could not serialize image cache:", err)
+		return
+	}
+	if err := ioutil.WriteFile(cacheFilePath(), data, 0644); err != nil {
+		log.Println("Could not write image cache:", err)
+	}
+}
+
+// refreshImageCache inspects every image known to the Docker daemon
+// (including untagged/intermediate ones) and records in the cache file which
+// of them are SUSE based.
+func refreshImageCache() {
+	client := getDockerClient()
+
+	imgs, err := client.ListImages(true)
+	if err != nil {
+		log.Println("List Failed:", err)
+		return
+	}
+
+	cd := &CacheData{Valid: true}
+	for _, img := range imgs {
+		if isSuseImage(img.Id) {
+			cd.Suse = append(cd.Suse, img.Id)
+		} else {
+			cd.Other = append(cd.Other, img.Id)
+		}
+	}
+	sort.Strings(cd.Suse)
+	sort.Strings(cd.Other)
+	cd.flush()
+}
+
+// isSuseImage runs `rpm -q zypper` inside a throwaway container to decide
+// whether the given image is SUSE based.
+func isSuseImage(id string) bool {
+	return checkCommandInImage(id, "rpm -q zypper")
+}
+
+// imagesCmd implements `zypper-docker images`. With --force it first
+// rebuilds the SUSE image cache from scratch; either way it then prints all
+// the currently tagged images known to the Docker daemon, without filtering
+// out the ones that aren't SUSE based, in the format given
+// by --format ("table", the default; "json"; or a Go text/template
+// expression applied per image).
+func imagesCmd(ctx *cli.Context) {
+	if ctx.Bool("force") {
+		refreshImageCache()
+	}
+
+	client := getDockerClient()
+	dockerImgs, err := client.ListImages(false)
+	if err != nil {
+		log.Println("List Failed:", err)
+		return
+	}
+
+	imgs := make([]Image, len(dockerImgs))
+	for i, img := range dockerImgs {
+		repo, tag := splitRepoTag(img)
+		imgs[i] = Image{
+			Repository: repo,
+			Tag:        tag,
+			ID:         img.Id,
+			Created:    "Less than a second ago",
+			Size:       humanizeSize(img.Size),
+		}
+	}
+
+	if err := renderImages(os.Stdout, imgs, ctx.String("format")); err != nil {
+		log.Println("Could not render image list:", err)
+	}
+}
+
+// splitRepoTag splits the first repo:tag pair of img into its components.
+func splitRepoTag(img *dockerclient.Image) (string, string) {
+	if len(img.RepoTags) == 0 {
+		return "<none>", "<none>"
+	}
+	parts := strings.SplitN(img.RepoTags[0], ":", 2)
+	if len(parts) != 2 {
+		return parts[0], "<none>"
+	}
+	return parts[0], parts[1]
+}
+
+// humanizeSize renders a byte count the same way `docker images` does.
+func humanizeSize(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/1000/1000)
+}
@@ -16,7 +16,7 @@ package main
 
 import (
 	"bytes"
-	"flag"
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"os"
@@ -26,16 +26,15 @@ import (
 	"time"
 
 	"github.com/codegangsta/cli"
+	"github.com/lightlace/zypper-docker/zyppertest"
 )
 
 func testContext(force bool) *cli.Context {
-	set := flag.NewFlagSet("test", 0)
-	set.Bool("force", force, "doc")
-	return cli.NewContext(nil, set, nil)
+	return zyppertest.Context(force, "")
 }
 
 func TestImagesCmdFail(t *testing.T) {
-	dockerClient = &mockClient{listFail: true}
+	dockerClient = zyppertest.New(zyppertest.WithListFailure())
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -51,7 +50,7 @@ func TestImagesCmdFail(t *testing.T) {
 }
 
 func TestImagesListEmpty(t *testing.T) {
-	dockerClient = &mockClient{listEmpty: true}
+	dockerClient = zyppertest.New(zyppertest.WithEmptyList())
 
 	temp, err := ioutil.TempFile("", "zypper")
 	if err != nil {
@@ -80,7 +79,7 @@ func TestImagesListEmpty(t *testing.T) {
 }
 
 func TestImagesListOk(t *testing.T) {
-	dockerClient = &mockClient{waitSleep: 100 * time.Millisecond}
+	dockerClient = zyppertest.New(zyppertest.WithWaitSleep(100 * time.Millisecond))
 
 	buffer := bytes.NewBuffer([]byte{})
 	log.SetOutput(buffer)
@@ -119,8 +118,96 @@ func TestImagesListOk(t *testing.T) {
 	}
 }
 
+// TestImagesForceWithTrustEnabled makes sure that enabling Docker Content
+// Trust doesn't interfere with the SUSE image cache refresh, which never
+// pulls or commits anything.
+func TestImagesForceWithTrustEnabled(t *testing.T) {
+	trustFlag = true
+	defer func() { trustFlag = false }()
+
+	dockerClient = zyppertest.New(zyppertest.WithWaitSleep(100 * time.Millisecond))
+
+	cache := os.Getenv("XDG_CACHE_HOME")
+	abs, _ := filepath.Abs(".")
+	test := filepath.Join(abs, "test")
+	defer func() {
+		_ = os.Setenv("XDG_CACHE_HOME", cache)
+		_ = os.Remove(filepath.Join(test, cacheName))
+	}()
+	_ = os.Setenv("XDG_CACHE_HOME", test)
+
+	refreshImageCache()
+	cd := getCacheFile()
+	if !cd.Valid {
+		t.Fatal("It should be valid")
+	}
+}
+
+func TestImagesListJSON(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	temp, err := ioutil.TempFile("", "zypper")
+	if err != nil {
+		t.Fatal("Could not setup test")
+	}
+	original := os.Stdout
+	os.Stdout = temp
+
+	imagesCmd(zyppertest.Context(false, "json"))
+	b, err := ioutil.ReadFile(temp.Name())
+
+	_ = temp.Close()
+	_ = os.Remove(temp.Name())
+	os.Stdout = original
+
+	if err != nil {
+		t.Fatal("Could not read temporary file")
+	}
+
+	var imgs []Image
+	if err := json.Unmarshal(b, &imgs); err != nil {
+		t.Fatalf("Output wasn't valid JSON: %v\n", err)
+	}
+	if len(imgs) != 2 {
+		t.Fatalf("Expected 2 images, got %d\n", len(imgs))
+	}
+	if imgs[0].Repository != "opensuse" || imgs[0].Tag != "latest" {
+		t.Fatalf("Unexpected first image: %+v\n", imgs[0])
+	}
+	if imgs[1].Repository != "opensuse" || imgs[1].Tag != "13.2" {
+		t.Fatalf("Unexpected second image: %+v\n", imgs[1])
+	}
+}
+
+func TestImagesListTemplate(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	temp, err := ioutil.TempFile("", "zypper")
+	if err != nil {
+		t.Fatal("Could not setup test")
+	}
+	original := os.Stdout
+	os.Stdout = temp
+
+	imagesCmd(zyppertest.Context(false, "{{.Repository}}:{{.Tag}}"))
+	b, err := ioutil.ReadFile(temp.Name())
+
+	_ = temp.Close()
+	_ = os.Remove(temp.Name())
+	os.Stdout = original
+
+	if err != nil {
+		t.Fatal("Could not read temporary file")
+	}
+
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "opensuse:latest" || lines[1] != "opensuse:13.2" {
+		t.Fatalf("Unexpected templated output: %q\n", string(b))
+	}
+}
+
 func TestImagesForce(t *testing.T) {
-	dockerClient = &mockClient{waitSleep: 100 * time.Millisecond}
+	dockerClient = zyppertest.New(zyppertest.WithWaitSleep(100 * time.Millisecond))
 
 	temp, err := ioutil.TempFile("", "zypper")
 	if err != nil {
@@ -169,4 +256,4 @@ func TestImagesForce(t *testing.T) {
 	_ = temp.Close()
 	_ = os.Remove(temp.Name())
 	os.Stdout = original
-}
\ No newline at end of file
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/mssola/dockerclient"
+)
+
+// dockerClient is the client used to talk to the Docker daemon. It is a
+// package level variable so that tests can swap it out for a
+// zyppertest.MockClient.
+var dockerClient DockerClient
+
+// getDockerClient returns the current Docker client, connecting to the local
+// daemon through its Unix socket the first time it's called.
+func getDockerClient() DockerClient {
+	if dockerClient != nil {
+		return dockerClient
+	}
+
+	client, err := dockerclient.NewDockerClient("unix:///var/run/docker.sock", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dockerClient = newRealDockerClient(client)
+	return dockerClient
+}
+
+// defaultHooksDir returns the directory zypper-docker looks for hook
+// executables in when --hooks-dir isn't given.
+func defaultHooksDir() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "zypper-docker", "hooks.d")
+}
+
+// newApp builds the zypper-docker cli.App: its flags, commands, and the
+// app.Before that turns parsed global flags into the package-level state
+// the rest of zypper-docker reads. Split out from main so tests can inspect
+// it without invoking app.Run.
+func newApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "zypper-docker"
+	app.Usage = "Patch Docker images with zypper"
+	app.Flags = []cli.Flag{
+		cli.BoolFlag{Name: "trust", Usage: "Enable Docker Content Trust for pulls and commits"},
+		cli.BoolFlag{Name: "disable-content-trust", Usage: "Disable Docker Content Trust, overriding DOCKER_CONTENT_TRUST"},
+		cli.StringFlag{Name: "zypp-cache", Usage: "Host directory to mount as /var/cache/zypp in the patch container"},
+		cli.StringFlag{Name: "hooks-dir", Value: defaultHooksDir(), Usage: "Directory of executables run on patching events"},
+		cli.StringFlag{Name: "webhook-url", Usage: "URL notified with a JSON payload on patching events"},
+	}
+	app.Before = func(ctx *cli.Context) error {
+		trustFlag = ctx.GlobalBool("trust")
+		disableTrustFlag = ctx.GlobalBool("disable-content-trust")
+		zyppCachePath = ctx.GlobalString("zypp-cache")
+
+		hooks = nil
+		if dir := ctx.GlobalString("hooks-dir"); dir != "" {
+			registerHook(newExecHook(dir))
+		}
+		if url := ctx.GlobalString("webhook-url"); url != "" {
+			registerHook(newHTTPHook(url))
+		}
+		return nil
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name:   "images",
+			Usage:  "List all the tagged images, SUSE based or not",
+			Action: imagesCmd,
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "force", Usage: "Force a refresh of the SUSE image cache"},
+				cli.StringFlag{Name: "format", Usage: "Format the output using the given Go template, or \"json\""},
+			},
+		},
+		{
+			Name:   "patch",
+			Usage:  "Patch a SUSE based image and commit the result: patch IMAGE [NEW-REPO:TAG]",
+			Action: patchCmd,
+		},
+	}
+
+	return app
+}
+
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAppRegistersPatchCommand guards against OnPrePatch/OnPostPatch
+// becoming unreachable again: patchCmd is the only caller of patchImage,
+// which is what actually fires them outside of tests, so the "patch"
+// command has to stay wired to it.
+func TestAppRegistersPatchCommand(t *testing.T) {
+	app := newApp()
+
+	var found bool
+	for _, cmd := range app.Commands {
+		if cmd.Name != "patch" {
+			continue
+		}
+		found = true
+		if reflect.ValueOf(cmd.Action).Pointer() != reflect.ValueOf(patchCmd).Pointer() {
+			t.Fatal("The patch command should be wired to patchCmd\n")
+		}
+	}
+	if !found {
+		t.Fatal("Expected a patch command to be registered\n")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/codegangsta/cli"
+)
+
+// patchImage runs `zypper patch` inside a throwaway container based on
+// image, and, on success, commits the result as repo:tag. When Content
+// Trust is enabled the pull is verified and the resulting image is signed,
+// by way of preparePatch and commitPatchedImage respectively.
+func patchImage(image, repo, tag string) (string, error) {
+	id, _, err := runPatchContainer(image, []string{"zypper", "--non-interactive", "patch"}, true)
+	if id != "" {
+		defer removeContainer(id)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return commitPatchedImage(id, repo, tag)
+}
+
+// splitImageRef splits a "repo:tag" command-line argument into its
+// components, defaulting tag to "latest" when none is given, matching
+// Docker's own convention for an untagged reference.
+func splitImageRef(ref string) (string, string) {
+	parts := strings.SplitN(ref, ":", 2)
+	if len(parts) != 2 {
+		return parts[0], "latest"
+	}
+	return parts[0], parts[1]
+}
+
+// patchCmd implements `zypper-docker patch IMAGE [NEW-REPO:TAG]`: it patches
+// IMAGE and commits the result, either back onto IMAGE's own repository or,
+// when a second argument is given, as NEW-REPO:TAG.
+func patchCmd(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 1 {
+		log.Fatal("Usage: zypper-docker patch IMAGE [NEW-REPO:TAG]")
+	}
+
+	image := args[0]
+	newRef := image
+	if len(args) > 1 {
+		newRef = args[1]
+	}
+	repo, tag := splitImageRef(newRef)
+
+	patched, err := patchImage(image, repo, tag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(patched)
+}
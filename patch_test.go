@@ -0,0 +1,85 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/lightlace/zypper-docker/zyppertest"
+)
+
+func TestSplitImageRefWithTag(t *testing.T) {
+	repo, tag := splitImageRef("opensuse:13.2")
+	if repo != "opensuse" || tag != "13.2" {
+		t.Fatalf("Unexpected repo/tag: %v/%v\n", repo, tag)
+	}
+}
+
+func TestSplitImageRefDefaultsToLatest(t *testing.T) {
+	repo, tag := splitImageRef("opensuse")
+	if repo != "opensuse" || tag != "latest" {
+		t.Fatalf("Unexpected repo/tag: %v/%v\n", repo, tag)
+	}
+}
+
+func TestPatchImageCommitsResult(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	image, err := patchImage("opensuse", "opensuse", "patched")
+	if err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+	if image != "opensuse:patched" {
+		t.Fatalf("Unexpected image reference: %v\n", image)
+	}
+}
+
+func TestPatchImageCommandFailure(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithCommandFailure())
+
+	if _, err := patchImage("opensuse", "opensuse", "patched"); err == nil {
+		t.Fatal("It should've failed\n")
+	}
+}
+
+func TestPatchImageSignFailure(t *testing.T) {
+	dockerClient = zyppertest.New(zyppertest.WithSignFailure())
+
+	if _, err := patchImage("opensuse", "opensuse", "patched"); err == nil {
+		t.Fatal("It should've failed\n")
+	}
+}
+
+func TestPatchImageFiresHooks(t *testing.T) {
+	dockerClient = zyppertest.New()
+
+	hook := &recordingHook{}
+	hooks = []Hook{hook}
+	defer func() { hooks = nil }()
+
+	if _, err := patchImage("opensuse", "opensuse", "patched"); err != nil {
+		t.Fatal("It shouldn't have failed\n")
+	}
+
+	if len(hook.prePatch) != 1 || hook.prePatch[0] != "opensuse" {
+		t.Fatal("Hook should've been notified before patching started\n")
+	}
+	if len(hook.postPatch) != 1 || hook.postPatch[0] != "opensuse:patched" {
+		t.Fatal("Hook should've been notified of the successful commit\n")
+	}
+	if len(hook.removed) != 1 {
+		t.Fatal("Hook should've been notified of the container removal\n")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lightlace/zypper-docker/registry"
+)
+
+// dockerConfigPath is where the Docker CLI itself stores registry auth.
+func dockerConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".docker", "config.json")
+}
+
+// zypperDockerConfigPath is zypper-docker's own config file, used for
+// settings Docker doesn't know about, such as mirrors.
+func zypperDockerConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "zypper-docker", "config.json")
+}
+
+// loadRegistryConfig merges ~/.docker/config.json and zypper-docker's own
+// config file into a single registry.Config. Either file is optional; a
+// missing or unreadable file just contributes nothing.
+func loadRegistryConfig() *registry.Config {
+	cfg := &registry.Config{Auths: map[string]registry.AuthConfig{}}
+
+	mergeConfigFile(cfg, dockerConfigPath())
+	mergeConfigFile(cfg, zypperDockerConfigPath())
+
+	return cfg
+}
+
+func mergeConfigFile(cfg *registry.Config, path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var parsed registry.Config
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		log.Println("Could not parse registry config", path, ":", err)
+		return
+	}
+
+	cfg.Mirrors = append(cfg.Mirrors, parsed.Mirrors...)
+	for host, auth := range parsed.Auths {
+		cfg.Auths[host] = auth
+	}
+}
+
+// pullImage pulls name, authenticating against the registries configured in
+// ~/.docker/config.json and the zypper-docker config file. If the primary
+// registry refuses the pull, each configured mirror is tried in turn.
+func pullImage(name string) error {
+	client := getDockerClient()
+	cfg := loadRegistryConfig()
+
+	err := client.PullImage(name, cfg)
+	if err == nil {
+		return nil
+	}
+	if !isAuthError(err) {
+		return err
+	}
+
+	for _, mirror := range cfg.Mirrors {
+		log.Println("Falling back to mirror", mirror)
+		if merr := client.PullImage(mirrorImageName(mirror, name), cfg); merr == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// isAuthError reports whether err looks like a registry authentication
+// failure, as opposed to some other reason a pull might fail.
+func isAuthError(err error) bool {
+	return strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "authentication required")
+}
+
+// mirrorImageName rewrites name to be pulled from mirror instead of its
+// original registry.
+func mirrorImageName(mirror, name string) string {
+	return strings.TrimRight(mirror, "/") + "/" + name
+}
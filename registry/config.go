@@ -0,0 +1,35 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registry holds the registry configuration types shared between
+// zypper-docker itself and its DockerClient test double in zyppertest, so
+// that neither has to import the other. It's a top-level package, not
+// nested under zyppertest or internal/, so that external consumers (the
+// openSUSE build service, signing pipelines, and the like) can depend on
+// it directly to construct a Config and implement DockerClient themselves.
+package registry
+
+// AuthConfig is a single entry of the "auths" map, as found in both
+// ~/.docker/config.json and zypper-docker's own config file.
+type AuthConfig struct {
+	Auth string `json:"auth"`
+}
+
+// Config holds everything zypper-docker knows about how to reach a
+// registry: credentials per host, plus an ordered list of mirrors to fall
+// back to when a pull from the primary registry is denied.
+type Config struct {
+	Mirrors []string              `json:"mirrors"`
+	Auths   map[string]AuthConfig `json:"auths"`
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// zyppCachePath backs the --zypp-cache flag: a host directory bind-mounted
+// into the private patch container as /var/cache/zypp, so that repository
+// and package metadata survive between invocations.
+var zyppCachePath string
+
+// selinuxEnabled reports whether the host is running with SELinux in
+// enforcing mode. It's a package level variable, rather than a plain
+// function, so that tests can stub it out without requiring an actual
+// SELinux-enabled kernel.
+var selinuxEnabled = func() bool {
+	data, err := ioutil.ReadFile("/sys/fs/selinux/enforce")
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(data)) == "1"
+}
+
+// bindsWithSELinux returns the Docker --volume style bind spec for mounting
+// host into container inside the patch container. On an SELinux-enforcing
+// host it appends a relabel suffix, without which the mount is denied by
+// AVC rules rather than being usable: :Z (private relabel) when shared is
+// false, for a mount only ever used by one container at a time, or :z
+// (shared relabel) when shared is true, for a mount meant to be accessed by
+// several containers concurrently.
+func bindsWithSELinux(host, container string, shared bool) string {
+	bind := host + ":" + container
+	if !selinuxEnabled() {
+		return bind
+	}
+	if shared {
+		return bind + ":z"
+	}
+	return bind + ":Z"
+}
+
+// zyppCacheBinds returns the Binds entries needed to mount the configured
+// zypper cache directory into a container, or nil if --zypp-cache wasn't
+// set. The cache is meant to be reused across concurrent zypper-docker
+// invocations, so it's mounted with the shared SELinux label.
+func zyppCacheBinds() []string {
+	if zyppCachePath == "" {
+		return nil
+	}
+	return []string{bindsWithSELinux(zyppCachePath, "/var/cache/zypp", true)}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"log"
+	"os"
+)
+
+// trustFlag and disableTrustFlag back the --trust and
+// --disable-content-trust global flags, set in the app.Before that newApp
+// installs, from the parsed cli.Context.
+var (
+	trustFlag        bool
+	disableTrustFlag bool
+)
+
+// trustEnabled reports whether Docker Content Trust should be enforced for
+// pulls and commits. --disable-content-trust always wins; otherwise either
+// --trust or DOCKER_CONTENT_TRUST=1 turns it on, matching the precedence the
+// Docker CLI itself uses.
+func trustEnabled() bool {
+	if disableTrustFlag {
+		return false
+	}
+	return trustFlag || os.Getenv("DOCKER_CONTENT_TRUST") == "1"
+}
+
+// commitPatchedImage commits the container identified by id as repo:tag. A
+// patched image is a new artifact, so when Content Trust is enabled it's
+// signed with the local notary keys before it's handed back for pushing.
+func commitPatchedImage(id, repo, tag string) (string, error) {
+	client := getDockerClient()
+
+	image, err := client.TrustedCommit(id, repo, tag)
+	if err != nil {
+		log.Println("Signing failed:", err)
+		return "", err
+	}
+	firePostPatch(repo, tag, nil)
+	return image, nil
+}
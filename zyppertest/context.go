@@ -0,0 +1,30 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zyppertest
+
+import (
+	"flag"
+
+	"github.com/codegangsta/cli"
+)
+
+// Context builds a *cli.Context for the `images` command as if it had been
+// invoked with --force=force and --format=format.
+func Context(force bool, format string) *cli.Context {
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("force", force, "doc")
+	set.String("format", format, "doc")
+	return cli.NewContext(nil, set, nil)
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2015 SUSE LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zyppertest provides a fake implementation of zypper-docker's
+// DockerClient interface, plus the CLI test scaffolding built on top of it,
+// so that third-party tooling embedding DockerClient can exercise it
+// without vendoring zypper-docker's package main.
+package zyppertest
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/lightlace/zypper-docker/registry"
+	"github.com/mssola/dockerclient"
+)
+
+// MockClient is a fake Docker client satisfying the same method set as
+// zypper-docker's DockerClient interface. Build one with New and the
+// With* options below, rather than setting fields directly.
+type MockClient struct {
+	createFail  bool
+	startFail   bool
+	logFail     bool
+	commandFail bool
+	waitFail    bool
+	waitSleep   time.Duration
+	removeFail  bool
+	listFail    bool
+	listEmpty   bool
+	trustFail   bool
+	signFail    bool
+	noImage     bool
+	authFail    bool
+
+	pulled         bool
+	mirrorHit      bool
+	lastHostConfig *dockerclient.HostConfig
+}
+
+// otherImageContainer is the name zypper-docker gives the private container
+// it runs in image "3", the one image reported by ListImages that isn't SUSE
+// based. Commands waited on in it exit with status 1, as `rpm -q zypper`
+// would there.
+const otherImageContainer = "zypper-docker-private-3"
+
+// Option configures a MockClient.
+type Option func(*MockClient)
+
+// WithCreateFailure makes CreateContainer fail.
+func WithCreateFailure() Option { return func(m *MockClient) { m.createFail = true } }
+
+// WithStartFailure makes StartContainer fail.
+func WithStartFailure() Option { return func(m *MockClient) { m.startFail = true } }
+
+// WithLogFailure makes ContainerLogs fail.
+func WithLogFailure() Option { return func(m *MockClient) { m.logFail = true } }
+
+// WithCommandFailure makes the container's command exit with a non-zero
+// status instead of succeeding.
+func WithCommandFailure() Option { return func(m *MockClient) { m.commandFail = true } }
+
+// WithWaitFailure makes Wait report an error.
+func WithWaitFailure() Option { return func(m *MockClient) { m.waitFail = true } }
+
+// WithWaitSleep makes Wait's result arrive after d, to simulate a
+// slow-running or hung container.
+func WithWaitSleep(d time.Duration) Option {
+	return func(m *MockClient) { m.waitSleep = d }
+}
+
+// WithRemoveFailure makes RemoveContainer fail.
+func WithRemoveFailure() Option { return func(m *MockClient) { m.removeFail = true } }
+
+// WithListFailure makes ListImages fail.
+func WithListFailure() Option { return func(m *MockClient) { m.listFail = true } }
+
+// WithEmptyList makes ListImages report no images at all.
+func WithEmptyList() Option { return func(m *MockClient) { m.listEmpty = true } }
+
+// WithTrustFailure makes TrustedPull fail, as if content trust verification
+// didn't pass.
+func WithTrustFailure() Option { return func(m *MockClient) { m.trustFail = true } }
+
+// WithSignFailure makes TrustedCommit fail, as if signing the committed
+// image didn't succeed.
+func WithSignFailure() Option { return func(m *MockClient) { m.signFail = true } }
+
+// WithMissingImage makes CreateContainer report "no such image" until
+// PullImage has succeeded once.
+func WithMissingImage() Option { return func(m *MockClient) { m.noImage = true } }
+
+// WithAuthFailure makes PullImage fail against the primary registry, as if
+// the daemon wasn't authorized to pull from it.
+func WithAuthFailure() Option { return func(m *MockClient) { m.authFail = true } }
+
+// New creates a MockClient configured by the given options.
+func New(opts ...Option) *MockClient {
+	m := &MockClient{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Pulled reports whether PullImage has succeeded at least once.
+func (m *MockClient) Pulled() bool {
+	return m.pulled
+}
+
+// MirrorHit reports whether the last successful PullImage was served by a
+// mirror rather than the primary registry.
+func (m *MockClient) MirrorHit() bool {
+	return m.mirrorHit
+}
+
+// LastHostConfig returns the HostConfig most recently passed to
+// StartContainer, or nil if it hasn't been called yet.
+func (m *MockClient) LastHostConfig() *dockerclient.HostConfig {
+	return m.lastHostConfig
+}
+
+func (m *MockClient) CreateContainer(config *dockerclient.ContainerConfig, name string) (string, error) {
+	if m.createFail {
+		return "", errors.New("Create failed")
+	}
+	if m.noImage && !m.pulled {
+		return "", errors.New("no such image: " + config.Image)
+	}
+	if name != "" {
+		return name, nil
+	}
+	return config.Image, nil
+}
+
+func (m *MockClient) StartContainer(id string, config *dockerclient.HostConfig) error {
+	m.lastHostConfig = config
+	if m.startFail {
+		return errors.New("Start failed")
+	}
+	return nil
+}
+
+func (m *MockClient) ContainerLogs(id string, options *dockerclient.LogOptions) (io.ReadCloser, error) {
+	if m.logFail {
+		return nil, errors.New("Fake log failure")
+	}
+	return ioutil.NopCloser(strings.NewReader("fake log output")), nil
+}
+
+func (m *MockClient) Wait(id string) <-chan dockerclient.WaitResult {
+	ch := make(chan dockerclient.WaitResult, 1)
+	go func() {
+		if m.waitSleep > 0 {
+			time.Sleep(m.waitSleep)
+		}
+		switch {
+		case m.waitFail:
+			ch <- dockerclient.WaitResult{Error: errors.New("Wait failed")}
+		case m.commandFail, id == otherImageContainer:
+			ch <- dockerclient.WaitResult{ExitCode: 1}
+		default:
+			ch <- dockerclient.WaitResult{ExitCode: 0}
+		}
+	}()
+	return ch
+}
+
+func (m *MockClient) RemoveContainer(id string, force, volumes bool) error {
+	if m.removeFail {
+		return errors.New("Remove failed")
+	}
+	return nil
+}
+
+func (m *MockClient) ListImages(all bool) ([]*dockerclient.Image, error) {
+	if m.listFail {
+		return nil, errors.New("List Failed")
+	}
+	if m.listEmpty {
+		return []*dockerclient.Image{}, nil
+	}
+
+	named := []*dockerclient.Image{
+		{Id: "1", RepoTags: []string{"opensuse:latest"}, Size: 254500000},
+		{Id: "2", RepoTags: []string{"opensuse:13.2"}, Size: 254500000},
+	}
+	if !all {
+		return named, nil
+	}
+
+	return append(named,
+		&dockerclient.Image{Id: "3", RepoTags: []string{"other:latest"}, Size: 254500000},
+		&dockerclient.Image{Id: "4", RepoTags: []string{"opensuse:foo"}, Size: 254500000},
+	), nil
+}
+
+func (m *MockClient) TrustedPull(image string) error {
+	if m.trustFail {
+		return errors.New("Trust verification failed")
+	}
+	return nil
+}
+
+func (m *MockClient) TrustedCommit(id, repo, tag string) (string, error) {
+	if m.signFail {
+		return "", errors.New("Signing failed")
+	}
+	return repo + ":" + tag, nil
+}
+
+func (m *MockClient) PullImage(name string, cfg *registry.Config) error {
+	if strings.Contains(name, "mirror.example.com") {
+		m.mirrorHit = true
+		m.pulled = true
+		return nil
+	}
+	if m.authFail {
+		return errors.New("unauthorized: authentication required")
+	}
+	m.mirrorHit = false
+	m.pulled = true
+	return nil
+}